@@ -0,0 +1,264 @@
+package gin_lane
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsnab/go-lane"
+)
+
+// The FastCGI record types/roles this test's minimal client needs, per
+// net/http/fcgi's private protocol constants.
+const (
+	fcgiVersion1        = 1
+	fcgiTypeBeginRequest = 1
+	fcgiTypeParams       = 4
+	fcgiTypeStdin        = 5
+	fcgiTypeStdout       = 6
+	fcgiTypeStderr       = 7
+	fcgiTypeEndRequest   = 3
+	fcgiRoleResponder    = 1
+)
+
+// fcgiWriteRecord writes one FastCGI record, padding content to a multiple
+// of 8 bytes as net/http/fcgi does.
+func fcgiWriteRecord(w io.Writer, recType uint8, reqId uint16, content []byte) error {
+	pad := (8 - len(content)%8) % 8
+	header := [8]byte{
+		fcgiVersion1, recType,
+		byte(reqId >> 8), byte(reqId),
+		byte(len(content) >> 8), byte(len(content)),
+		byte(pad), 0,
+	}
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(content); err != nil {
+		return err
+	}
+	if pad > 0 {
+		if _, err := w.Write(make([]byte, pad)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fcgiEncodeParamSize(buf *bytes.Buffer, n int) {
+	if n <= 127 {
+		buf.WriteByte(byte(n))
+		return
+	}
+	var sz [4]byte
+	binary.BigEndian.PutUint32(sz[:], uint32(n)|0x80000000)
+	buf.Write(sz[:])
+}
+
+// fcgiEncodeParams encodes CGI environment variables in FastCGI's
+// length-prefixed name/value form.
+func fcgiEncodeParams(params map[string]string) []byte {
+	var buf bytes.Buffer
+	for k, v := range params {
+		fcgiEncodeParamSize(&buf, len(k))
+		fcgiEncodeParamSize(&buf, len(v))
+		buf.WriteString(k)
+		buf.WriteString(v)
+	}
+	return buf.Bytes()
+}
+
+// fcgiRequest drives a single FastCGI responder request over addr the way
+// nginx's fastcgi_pass would, and returns the CGI-style response: a status
+// line and headers, followed by the body.
+func fcgiRequest(t *testing.T, addr string, method string, uri string, body []byte, extraHeaders map[string]string) (status int, header http.Header, respBody []byte) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	const reqId = 1
+
+	begin := [8]byte{0, fcgiRoleResponder, 0, 0, 0, 0, 0, 0}
+	if err := fcgiWriteRecord(conn, fcgiTypeBeginRequest, reqId, begin[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	params := map[string]string{
+		"REQUEST_METHOD":  method,
+		"REQUEST_URI":     uri,
+		"SERVER_PROTOCOL": "HTTP/1.1",
+		"HTTP_HOST":       "fcgi-test",
+	}
+	if len(body) > 0 {
+		params["CONTENT_LENGTH"] = strconv.Itoa(len(body))
+	}
+	for k, v := range extraHeaders {
+		params["HTTP_"+strings.ToUpper(strings.ReplaceAll(k, "-", "_"))] = v
+	}
+
+	if err := fcgiWriteRecord(conn, fcgiTypeParams, reqId, fcgiEncodeParams(params)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fcgiWriteRecord(conn, fcgiTypeParams, reqId, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(body) > 0 {
+		if err := fcgiWriteRecord(conn, fcgiTypeStdin, reqId, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fcgiWriteRecord(conn, fcgiTypeStdin, reqId, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	r := bufio.NewReader(conn)
+	for {
+		var h [8]byte
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			t.Fatal(err)
+		}
+		recType := h[1]
+		contentLen := int(h[4])<<8 | int(h[5])
+		padLen := int(h[6])
+
+		content := make([]byte, contentLen)
+		if contentLen > 0 {
+			if _, err := io.ReadFull(r, content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if padLen > 0 {
+			if _, err := io.ReadFull(r, make([]byte, padLen)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		switch recType {
+		case fcgiTypeStdout:
+			stdout.Write(content)
+		case fcgiTypeEndRequest:
+			resp, err := parseCGIResponse(stdout.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp.status, resp.header, resp.body
+		}
+	}
+}
+
+type cgiResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// parseCGIResponse parses the CGI-style "Status: NNN reason\r\nHeader: v\r\n\r\nbody"
+// output net/http/fcgi's response writer produces onto the FastCGI stdout stream.
+func parseCGIResponse(raw []byte) (cgiResponse, error) {
+	reader := bufio.NewReader(bytes.NewReader(raw))
+	tp := textproto.NewReader(reader)
+
+	statusLine, err := tp.ReadLine()
+	if err != nil {
+		return cgiResponse{}, err
+	}
+	_, statusText, _ := strings.Cut(statusLine, " ")
+	statusCode, _, _ := strings.Cut(strings.TrimSpace(statusText), " ")
+	status, err := strconv.Atoi(statusCode)
+	if err != nil {
+		return cgiResponse{}, err
+	}
+
+	header := http.Header{}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return cgiResponse{}, err
+		}
+		if line == "" {
+			break
+		}
+		name, value, _ := strings.Cut(line, ":")
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return cgiResponse{}, err
+	}
+	return cgiResponse{status: status, header: header, body: body}, nil
+}
+
+func TestRunFCGIWithContext(t *testing.T) {
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	l, cancelFn := tl.DeriveWithCancel()
+	defer cancelFn()
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionLogRequestResult | GinLaneOptionDumpRequest | GinLaneOptionDumpResponse)
+	router := NewGinRouter(l, opt)
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	shutdownComplete := make(chan error, 1)
+	go func() {
+		shutdownComplete <- RunFCGIWithContext(l, router, ln)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	status, _, body := fcgiRequest(t, addr, "GET", "/ping", nil, map[string]string{"X-Vault-Token": "abc123"})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if string(body) != "pong" {
+		t.Fatalf("expected pong, got %q", body)
+	}
+
+	text := tl.EventsToString()
+	if !strings.Contains(text, `request: client=`) || !strings.Contains(text, `GET "/ping" status 200`) {
+		t.Fatal("request-result trace missing for fcgi request")
+	}
+	if !strings.Contains(text, "X-Vault-Token: ********") {
+		t.Fatal("header redaction did not apply to fcgi request dump")
+	}
+	if !strings.Contains(text, "response-data: HTTP/1.1 200") {
+		t.Fatal("response dump missing for fcgi request")
+	}
+
+	cancelFn()
+	select {
+	case err := <-shutdownComplete:
+		if err != http.ErrServerClosed {
+			t.Fatalf("expected http.ErrServerClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fcgi server failed to shut down in a reasonable time")
+	}
+}
@@ -0,0 +1,181 @@
+package gin_lane
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsnab/go-lane"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateSelfSignedCert returns a self-signed certificate for localhost
+// with the given serial number, suitable for tests only.
+func generateSelfSignedCert(t *testing.T, serial int64) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        cert,
+	}
+}
+
+// reloadableCertProvider is a TLSConfigProvider that can be swapped at runtime.
+type reloadableCertProvider struct {
+	cert tls.Certificate
+}
+
+func (p *reloadableCertProvider) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return &p.cert, nil
+}
+
+// peerSerial performs a GET over TLS and returns the serial number of the
+// certificate the server presented. Completing a full request/response,
+// rather than just the handshake, lets the connection settle into the idle
+// state the server's graceful shutdown expects.
+func peerSerial(t *testing.T, addr string) *big.Int {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	resp, err := client.Get("https://" + addr + "/ping")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.NotNil(t, resp.TLS)
+	require.NotEmpty(t, resp.TLS.PeerCertificates)
+	return resp.TLS.PeerCertificates[0].SerialNumber
+}
+
+func TestRunTLSWithContext(t *testing.T) {
+	cert := generateSelfSignedCert(t, 1)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writePemCert(t, certFile, cert.Certificate[0])
+	writePemKey(t, keyFile, cert.PrivateKey.(*ecdsa.PrivateKey))
+
+	tl := lane.NewLogLane(nil)
+	l, cancelFn := tl.DeriveWithCancel()
+	defer cancelFn()
+
+	engine := gin.New()
+	engine.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	addr := "127.0.0.1:8601"
+	shutdownComplete := make(chan struct{})
+	go func() {
+		err := RunTLSWithContext(l, engine, addr, certFile, keyFile)
+		assert.ErrorIs(t, err, http.ErrServerClosed)
+		close(shutdownComplete)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, cert.Leaf.SerialNumber, peerSerial(t, addr))
+
+	cancelFn()
+	select {
+	case <-shutdownComplete:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server failed to shut down in a reasonable time")
+	}
+}
+
+func TestRunWithListenerReloadableCert(t *testing.T) {
+	cert1 := generateSelfSignedCert(t, 1)
+	cert2 := generateSelfSignedCert(t, 2)
+
+	provider := &reloadableCertProvider{cert: cert1}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tlsLn := tls.NewListener(ln, NewReloadableTLSConfig(provider))
+
+	tl := lane.NewTestingLane(context.Background())
+	l, cancelFn := tl.DeriveWithCancel()
+	defer cancelFn()
+
+	engine := gin.New()
+	engine.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	shutdownComplete := make(chan struct{})
+	go func() {
+		err := RunWithListener(l, engine, tlsLn)
+		assert.ErrorIs(t, err, http.ErrServerClosed)
+		close(shutdownComplete)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	addr := ln.Addr().String()
+
+	assert.Equal(t, cert1.Leaf.SerialNumber, peerSerial(t, addr))
+
+	// swap the certificate without restarting the listener
+	provider.cert = cert2
+
+	assert.Equal(t, cert2.Leaf.SerialNumber, peerSerial(t, addr))
+
+	cancelFn()
+	select {
+	case <-shutdownComplete:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server failed to shut down in a reasonable time")
+	}
+}
+
+func writePemCert(t *testing.T, path string, der []byte) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func writePemKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, pem.Encode(f, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
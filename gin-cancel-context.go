@@ -1,14 +1,26 @@
 package gin_lane
 
 import (
+	"crypto/tls"
 	"errors"
+	"log"
+	"net"
 	"net/http"
+	"net/http/fcgi"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimsnab/go-lane"
 )
 
+// TLSConfigProvider supplies a certificate for each incoming TLS handshake,
+// allowing the certificate to be swapped out (e.g. on renewal) without
+// restarting the listener. Implementations must be safe for concurrent use.
+type TLSConfigProvider interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
 // RunWithContext starts the Gin server and serves until the lane is canceled.
 // It gracefully shuts down the server when the lane is canceled and returns http.ErrServerClosed.
 func RunWithContext(l lane.Lane, engine *gin.Engine, addr string) error {
@@ -17,12 +29,136 @@ func RunWithContext(l lane.Lane, engine *gin.Engine, addr string) error {
 		Handler: engine,
 	}
 
+	return runServerWithContext(l, srv, func() error {
+		l.Infof("server is running on %s", addr)
+		return srv.ListenAndServe()
+	})
+}
+
+// RunTLSWithContext starts the Gin server over TLS, with HTTP/2 enabled, and serves
+// until the lane is canceled. Certificates are loaded once from certFile/keyFile; use
+// RunWithListener with a TLSConfigProvider if certificates need to be reloaded without
+// restarting the listener.
+func RunTLSWithContext(l lane.Lane, engine *gin.Engine, addr string, certFile string, keyFile string) error {
+	srv := &http.Server{
+		Addr:     addr,
+		Handler:  engine,
+		ErrorLog: log.New(&laneWriter{l: l, isError: true}, "", 0),
+		TLSConfig: &tls.Config{
+			NextProtos: []string{"h2", "http/1.1"},
+		},
+	}
+
+	return runServerWithContext(l, srv, func() error {
+		l.Infof("server is running on %s (tls)", addr)
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// RunWithListener serves the Gin engine on the given listener until the lane is
+// canceled. Pass a listener wrapped with tls.NewListener, configured with a
+// TLSConfigProvider's GetCertificate as its GetCertificate callback, to support
+// certificate reload without dropping the listener.
+func RunWithListener(l lane.Lane, engine *gin.Engine, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:  engine,
+		ErrorLog: log.New(&laneWriter{l: l, isError: true}, "", 0),
+	}
+
+	return runServerWithContext(l, srv, func() error {
+		l.Infof("server is running on %s", ln.Addr())
+		return srv.Serve(ln)
+	})
+}
+
+// RunFCGIWithContext serves the Gin engine as a FastCGI responder on ln
+// (e.g. a Unix socket or TCP listener nginx is configured to talk FastCGI
+// to) until the lane is canceled. Per-request lane derivation works exactly
+// as it does for RunWithContext, since net/http/fcgi.Serve builds a regular
+// *http.Request and dispatches it through the same gin.Engine/middleware
+// chain; handler panics are likewise caught by gin.Recovery() and logged
+// through laneWriter via gin.DefaultErrorWriter; net/http/fcgi has no
+// ErrorLog hook of its own to wire up.
+//
+// Unlike http.Server, net/http/fcgi has no graceful Shutdown, so cancellation
+// closes ln to stop accepting new connections and then waits up to 30 seconds
+// for in-flight requests to finish.
+func RunFCGIWithContext(l lane.Lane, engine *gin.Engine, ln net.Listener) error {
+	var wg sync.WaitGroup
+	handler := &fcgiDrainHandler{next: engine, wg: &wg}
+
+	errChan := make(chan error, 1)
+	go func() {
+		l.Infof("fcgi server is running on %s", ln.Addr())
+		if err := fcgi.Serve(ln, handler); err != nil && !errors.Is(err, net.ErrClosed) {
+			errChan <- err
+		}
+		close(errChan)
+	}()
+
+	select {
+	case <-l.Done():
+		l.Infof("shutdown signal received, shutting down fcgi server...")
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			l.Errorf("fcgi listener close failed: %v", err)
+			return err
+		}
+
+		drained := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(drained)
+		}()
+
+		// l is already canceled here, so a context derived from it (as
+		// runServerWithContext derives shutdownCtx) would be immediately
+		// Done; use a plain timer instead to actually bound the wait.
+		select {
+		case <-drained:
+		case <-time.After(30 * time.Second):
+			l.Errorf("fcgi server shutdown timed out waiting for in-flight requests")
+		}
+
+		<-errChan
+		l.Infof("shutdown complete")
+		return http.ErrServerClosed
+	case err := <-errChan:
+		l.Errorf("fcgi server error: %v", err)
+		return err
+	}
+}
+
+// fcgiDrainHandler tracks in-flight requests so RunFCGIWithContext can wait
+// for them to finish before returning on shutdown.
+type fcgiDrainHandler struct {
+	next http.Handler
+	wg   *sync.WaitGroup
+}
+
+func (h *fcgiDrainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.wg.Add(1)
+	defer h.wg.Done()
+	h.next.ServeHTTP(w, r)
+}
+
+// NewReloadableTLSConfig builds a *tls.Config wired to consult provider's
+// GetCertificate on every handshake, with NextProtos set to prefer HTTP/2.
+func NewReloadableTLSConfig(provider TLSConfigProvider) *tls.Config {
+	return &tls.Config{
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: provider.GetCertificate,
+	}
+}
+
+// runServerWithContext runs serveFn in the background and waits for either the
+// lane to be canceled, in which case it gracefully shuts srv down, or for
+// serveFn to return an error on its own.
+func runServerWithContext(l lane.Lane, srv *http.Server, serveFn func() error) error {
 	// channel to listen for server errors
 	errChan := make(chan error, 1)
 
 	go func() {
-		l.Infof("server is running on %s", addr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := serveFn(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errChan <- err
 		}
 		close(errChan)
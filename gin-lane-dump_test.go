@@ -0,0 +1,89 @@
+package gin_lane
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimsnab/go-lane"
+)
+
+// waitForEventText polls for a TRACE event exactly matching msg via
+// FindEventText, which locks internally, unlike EventsToString - so this can
+// safely run concurrently with the handler goroutine still appending events.
+// The request/response dump is traced after the handler's Write calls
+// return, so for large bodies the client can finish reading its response
+// slightly before the server-side trace line lands. Once msg is observed,
+// the lane is known to be done writing it, so a single final EventsToString
+// call is safe for the caller's own substring checks.
+func waitForEventText(t *testing.T, tl lane.TestingLane, msg string) string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if tl.FindEventText("TRACE\t"+msg) || time.Now().After(deadline) {
+			return tl.EventsToString()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLargeRequestBodyCaptureIsBounded(t *testing.T) {
+	tl, _ := testServer(t, GinLaneOptionDumpRequestBody, false)
+
+	const size = 10 * 1024 * 1024
+	payload := bytes.Repeat([]byte("a"), size)
+
+	resp, err := http.Post("http://localhost:8600/echo", "text/plain", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	echoed, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(echoed) != size {
+		t.Fatalf("handler did not see the full body via the tee: got %d bytes, want %d", len(echoed), size)
+	}
+
+	expectedTruncated := size - DefaultMaxDumpBytes
+	truncMsg := fmt.Sprintf("request-data: ... [truncated %d bytes]", expectedTruncated)
+	text := waitForEventText(t, tl, truncMsg)
+	if !strings.Contains(text, truncMsg) {
+		t.Fatal("request dump did not report a bounded, truncated capture")
+	}
+	if strings.Count(text, "a") > DefaultMaxDumpBytes*2 {
+		t.Fatal("captured dump text looks unbounded")
+	}
+}
+
+func TestNonTextRequestBodySummarized(t *testing.T) {
+	tl, _ := testServer(t, GinLaneOptionDumpRequestBody, false)
+
+	payload := []byte{0x00, 0x01, 0x02, 0x03}
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:8600/echo", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	summaryMsg := "request-data: [non-text content-type application/octet-stream, 4 bytes, first 4 hex: 00010203]"
+	text := waitForEventText(t, tl, summaryMsg)
+	if !strings.Contains(text, summaryMsg) {
+		t.Fatal("binary request body was not summarized")
+	}
+	if strings.Contains(text, string(payload)) {
+		t.Fatal("raw binary bytes should not be dumped as text")
+	}
+}
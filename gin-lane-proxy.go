@@ -0,0 +1,217 @@
+package gin_lane
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsnab/go-lane"
+)
+
+// proxyLaneContextKey is the context key laneRoundTripper uses to recover the
+// per-request lane from the outbound request it's given. httputil.ReverseProxy
+// always wraps that request's context in an additional httptrace.WithClientTrace
+// value layer before calling Transport.RoundTrip, so the `ctx.(lane.Lane)` type
+// assertion used everywhere else in this package won't succeed there; ctx.Value
+// still finds the lane through that extra layer.
+type proxyLaneContextKey struct{}
+
+// NewLaneReverseProxy returns a gin.HandlerFunc that forwards the request to
+// target using an httputil.ReverseProxy. The lane derived for the inbound
+// request is propagated to the outbound request's context, and the same
+// GinLaneOptionDumpRequest*/GinLaneOptionDumpResponse* behavior that
+// UseLaneMiddleware applies to the inbound leg is applied to the upstream
+// leg as well.
+func NewLaneReverseProxy(l lane.Lane, target *url.URL, opt GinLaneOptions) gin.HandlerFunc {
+	return NewLaneReverseProxyWithConfig(l, target, opt, GinLaneConfig{})
+}
+
+// NewLaneReverseProxyWithConfig is NewLaneReverseProxy with explicit control
+// over the upstream leg's dump body capture limit and redaction via cfg, the
+// same as UseLaneMiddlewareWithConfig does for the inbound leg.
+func NewLaneReverseProxyWithConfig(l lane.Lane, target *url.URL, opt GinLaneOptions, cfg GinLaneConfig) gin.HandlerFunc {
+	rt := &laneRoundTripper{
+		fallback: l,
+		opt:      opt,
+		cfg:      cfg,
+		next:     http.DefaultTransport,
+	}
+
+	// The ReverseProxy and its Transport/ErrorHandler are built once and
+	// never mutated again: they're shared across every request this handler
+	// serves, so any per-request state has to live on the *http.Request
+	// context instead, recovered via proxyLaneContextKey in laneRoundTripper.
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path, req.URL.RawPath = joinURLPath(target, req.URL)
+			if _, ok := req.Header["User-Agent"]; !ok {
+				req.Header.Set("User-Agent", "")
+			}
+			req.Host = target.Host
+		},
+		Transport: rt,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			rt.laneFor(req).Errorf("proxy error: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+		// ErrorLog catches errors ErrorHandler can't, such as a read error
+		// from the upstream body after headers are already flushed to the
+		// client. It has no access to the request, so unlike ErrorHandler
+		// it's attributed to the base/fallback lane, the same as
+		// gin.DefaultErrorWriter is elsewhere in this package.
+		ErrorLog: log.New(&laneWriter{l: l, isError: true}, "", 0),
+	}
+
+	return func(c *gin.Context) {
+		l2, ok := c.Request.Context().(lane.Lane)
+		if !ok {
+			l2 = l.Derive()
+		}
+
+		outReq := c.Request.Clone(context.WithValue(l2, proxyLaneContextKey{}, l2))
+		// clear the inbound RequestURI so the upstream dump's request line
+		// is built from the rewritten outbound URL
+		outReq.RequestURI = ""
+		proxy.ServeHTTP(c.Writer, outReq)
+	}
+}
+
+// laneRoundTripper wraps the outbound transport so the request/response legs
+// to the upstream can be dumped the same bounded, redacted way the inbound
+// leg is, and so transport errors are logged to the lane's debug stream. It's
+// built once per NewLaneReverseProxy call and shared across every request, so
+// it holds no per-request state of its own - the lane for a given round trip
+// is recovered from that request's context.
+type laneRoundTripper struct {
+	fallback lane.Lane
+	opt      GinLaneOptions
+	cfg      GinLaneConfig
+	next     http.RoundTripper
+}
+
+// laneFor recovers the per-request lane stashed on req's context by
+// NewLaneReverseProxyWithConfig, falling back to the lane the proxy handler
+// was constructed with if, for some reason, it isn't there.
+func (rt *laneRoundTripper) laneFor(req *http.Request) lane.Lane {
+	if l2, ok := req.Context().Value(proxyLaneContextKey{}).(lane.Lane); ok {
+		return l2
+	}
+	return rt.fallback
+}
+
+func (rt *laneRoundTripper) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	l2 := rt.laneFor(req)
+	redactor := rt.cfg.redactor()
+
+	wantReqDump := (rt.opt & (GinLaneOptionDumpRequest | GinLaneOptionDumpRequestBody)) != 0
+	wantReqBody := (rt.opt & GinLaneOptionDumpRequestBody) != 0
+
+	var reqTee *teeReadCloser
+	if wantReqBody && req.Body != nil {
+		reqTee = newTeeReadCloser(req.Body, rt.cfg.maxDumpBytes())
+		req.Body = reqTee
+	}
+
+	resp, err = rt.next.RoundTrip(req)
+
+	if wantReqDump {
+		head := dumpRequestHead(req, redactor)
+		var body []byte
+		total := 0
+		if reqTee != nil {
+			body = reqTee.buf.Bytes()
+			total = reqTee.total
+		}
+		emitDump(l2, "upstream-request-data", head, req.Header.Get("Content-Type"), body, total, wantReqBody, redactor)
+	}
+
+	if err != nil {
+		l2.Debugf("upstream round trip retry/error: %v", err)
+		return
+	}
+
+	wantRespDump := (rt.opt & (GinLaneOptionDumpResponse | GinLaneOptionDumpResponseBody)) != 0
+	if wantRespDump {
+		wantRespBody := (rt.opt & GinLaneOptionDumpResponseBody) != 0
+		head := dumpResponseHead(resp.ProtoMajor, resp.ProtoMinor, resp.StatusCode, resp.Header, redactor)
+		contentType := resp.Header.Get("Content-Type")
+
+		if !wantRespBody || resp.Body == nil {
+			emitDump(l2, "upstream-response-data", head, contentType, nil, 0, wantRespBody, redactor)
+		} else {
+			respTee := newTeeReadCloser(resp.Body, rt.cfg.maxDumpBytes())
+			resp.Body = &dumpOnCloseBody{
+				teeReadCloser: respTee,
+				emit: func() {
+					emitDump(l2, "upstream-response-data", head, contentType, respTee.buf.Bytes(), respTee.total, true, redactor)
+				},
+			}
+		}
+	}
+
+	return
+}
+
+// dumpOnCloseBody defers response-dump emission until the proxied response
+// body is closed, which httputil.ReverseProxy.ServeHTTP always does right
+// after it finishes copying the body to the client. That makes Close a safe
+// "body fully sent" hook, and lets the dump report the body without
+// buffering it ahead of the client the way httputil.DumpResponse does.
+type dumpOnCloseBody struct {
+	*teeReadCloser
+	once sync.Once
+	emit func()
+}
+
+func (d *dumpOnCloseBody) Close() error {
+	err := d.teeReadCloser.Close()
+	d.once.Do(d.emit)
+	return err
+}
+
+// joinURLPath mirrors the path-joining behavior of the standard library's
+// unexported httputil helper so that proxying to a target with its own
+// path prefix behaves the same way NewSingleHostReverseProxy does. path is
+// built from the unescaped a.Path/b.Path and rawPath from the escaped
+// apath/bpath - using the escaped form for both, as this used to, re-escapes
+// any percent-escaped reserved character already in path when RawPath is
+// later derived from it, double-encoding it on the wire.
+func joinURLPath(a *url.URL, b *url.URL) (path, rawPath string) {
+	if a.RawPath == "" && b.RawPath == "" {
+		return singleJoiningSlash(a.Path, b.Path), ""
+	}
+	apath := a.EscapedPath()
+	bpath := b.EscapedPath()
+
+	aslash := len(apath) > 0 && apath[len(apath)-1] == '/'
+	bslash := len(bpath) > 0 && bpath[0] == '/'
+
+	switch {
+	case aslash && bslash:
+		return a.Path + b.Path[1:], apath + bpath[1:]
+	case !aslash && !bslash:
+		return a.Path + "/" + b.Path, apath + "/" + bpath
+	default:
+		return a.Path + b.Path, apath + bpath
+	}
+}
+
+func singleJoiningSlash(a, b string) string {
+	aslash := bytes.HasSuffix([]byte(a), []byte("/"))
+	bslash := bytes.HasPrefix([]byte(b), []byte("/"))
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	default:
+		return a + b
+	}
+}
@@ -0,0 +1,296 @@
+package gin_lane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jimsnab/go-lane"
+)
+
+func TestLaneReverseProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hello from upstream"))
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionLogRequestResult | GinLaneOptionDumpRequest | GinLaneOptionDumpResponse)
+	router := NewGinRouter(tl, opt)
+	router.GET("/proxied", NewLaneReverseProxy(tl, target, opt))
+
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	resp, err := http.Get(proxyServer.URL + "/proxied")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	text := tl.EventsToString()
+	if !strings.Contains(text, `request: client=`) || !strings.Contains(text, `GET "/proxied" status 200`) {
+		t.Fatal("middleware request-result trace missing for proxied route")
+	}
+	if !strings.Contains(text, "upstream-request-data: GET /") {
+		t.Fatal("upstream request leg not dumped")
+	}
+	if !strings.Contains(text, "upstream-response-data: HTTP/1.1 200 OK") {
+		t.Fatal("upstream response leg not dumped")
+	}
+}
+
+func TestLaneReverseProxyErrorHandler(t *testing.T) {
+	target, err := url.Parse("http://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionLogNone)
+	router := NewGinRouter(tl, opt)
+	router.GET("/proxied", NewLaneReverseProxy(tl, target, opt))
+
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	resp, err := http.Get(proxyServer.URL + "/proxied")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(tl.EventsToString(), "proxy error") {
+		t.Fatal("proxy ErrorHandler did not route through the lane")
+	}
+}
+
+// TestLaneReverseProxyBodyCopyErrorLog covers an error ErrorHandler can't:
+// the upstream connection dropping after headers are already flushed to the
+// client. httputil.ReverseProxy reports that through its ErrorLog, which
+// must be wired to the lane the same way ErrorHandler is, or it falls back
+// to the global log package (stderr) instead.
+func TestLaneReverseProxyBodyCopyErrorLog(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("short"))
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionLogNone)
+	router := NewGinRouter(tl, opt)
+	router.GET("/proxied", NewLaneReverseProxy(tl, target, opt))
+
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	resp, err := http.Get(proxyServer.URL + "/proxied")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	io.ReadAll(resp.Body) // expected to fail: the body is truncated short of Content-Length
+
+	if !strings.Contains(tl.EventsToString(), "read error during body copy") {
+		t.Fatal("ReverseProxy's ErrorLog did not route through the lane")
+	}
+}
+
+// TestLaneReverseProxyEscapedPath guards against joinURLPath re-escaping an
+// already-escaped reserved character in the proxied path. A request for
+// ".../a%2Fb" must reach the upstream as "a%2Fb", not the double-encoded
+// "a%252Fb" - which is what a Path built from the escaped join, rather than
+// the unescaped one, produces.
+func TestLaneReverseProxyEscapedPath(t *testing.T) {
+	var gotRequestURI string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestURI = r.RequestURI
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL + "/base/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionLogNone)
+	router := NewGinRouter(tl, opt)
+	router.Any("/proxied/*path", NewLaneReverseProxy(tl, target, opt))
+
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	resp, err := http.Get(proxyServer.URL + "/proxied/a%2Fb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if gotRequestURI != "/base/proxied/a%2Fb" {
+		t.Fatalf("expected upstream request-uri /base/proxied/a%%2Fb, got %s", gotRequestURI)
+	}
+}
+
+// TestLaneReverseProxyConcurrent guards against the data race that shows up
+// if NewLaneReverseProxy's *httputil.ReverseProxy is built once but its
+// Transport/ErrorHandler are reassigned on every request: 50 concurrent
+// requests each derive their own lane, and every request's upstream response
+// must be dumped. Run with -race to catch such a reassignment race.
+func TestLaneReverseProxyConcurrent(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hello from upstream"))
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionLogRequestResult | GinLaneOptionDumpRequest | GinLaneOptionDumpResponse)
+	router := NewGinRouter(tl, opt)
+	router.GET("/proxied", NewLaneReverseProxy(tl, target, opt))
+
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	const concurrency = 50
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(proxyServer.URL + "/proxied")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("expected 200, got %d", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	text := tl.EventsToString()
+	if strings.Count(text, "upstream-response-data: HTTP/1.1 200 OK") != concurrency {
+		t.Fatal("not every concurrent request's upstream response was dumped")
+	}
+}
+
+func TestLaneReverseProxyWithConfig(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hello from upstream"))
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	opt := GinLaneOptions(GinLaneOptionDumpRequest | GinLaneOptionDumpResponse)
+	cfg := GinLaneConfig{Redactor: NewDefaultRedactor(nil, nil, nil)}
+	router := NewGinRouter(tl, opt)
+	router.GET("/proxied", NewLaneReverseProxyWithConfig(tl, target, opt, cfg))
+
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, proxyServer.URL+"/proxied", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-token")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	text := tl.EventsToString()
+	if !strings.Contains(text, "upstream-request-data: Authorization: ********") {
+		t.Fatal("upstream request leg did not apply the configured redactor")
+	}
+	if strings.Contains(text, "secret-token") {
+		t.Fatal("raw authorization value leaked into the upstream dump")
+	}
+}
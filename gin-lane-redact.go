@@ -0,0 +1,193 @@
+package gin_lane
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// Redactor scrubs sensitive data out of the header and body text that the
+// lane middleware dumps for request/response tracing. Callers register a
+// custom Redactor via GinLaneConfig.Redactor; when left nil, the middleware
+// falls back to defaultRedactor, which reproduces the built-in suffix-based
+// header redaction this package has always had.
+type Redactor interface {
+	// RedactHeader returns the value to trace for a header with the given
+	// (already canonicalized) name. It is also responsible for masking
+	// Cookie and Set-Cookie values, since those aren't simple opaque
+	// strings.
+	RedactHeader(name, value string) string
+
+	// RedactBody returns the body bytes to trace. Implementations that
+	// don't understand contentType should return body unchanged.
+	RedactBody(contentType string, body []byte) []byte
+}
+
+// legacyRedactedHeaderSuffixes mirrors the header-name patterns kRedactExp
+// has always matched: an exact "authorization" header, or any header name
+// ending in one of these suffixes.
+var legacyRedactedHeaderSuffixes = []string{"-token", "-auth", "-key", "-sess", "-secret"}
+
+// defaultRedactor is the built-in Redactor used when a GinLaneConfig doesn't
+// supply one. With no options it only redacts the handful of header
+// patterns this package has always redacted. Configuring headerGlobs,
+// cookieNames, or jsonPaths layers on structured scrubbing of headers,
+// cookies, and JSON request/response bodies.
+type defaultRedactor struct {
+	headerGlobs []string
+	cookieNames map[string]bool
+	jsonPaths   []string
+}
+
+// NewDefaultRedactor builds a Redactor that masks headers matching any of
+// headerGlobs (case-insensitive path.Match patterns against the header
+// name, e.g. "X-*-Token"), masks the named cookies wherever they appear in
+// Cookie/Set-Cookie headers, and masks the given JSON paths (e.g.
+// "$.password") in application/json bodies. Any of the three may be nil.
+func NewDefaultRedactor(headerGlobs []string, cookieNames []string, jsonPaths []string) Redactor {
+	names := make(map[string]bool, len(cookieNames))
+	for _, name := range cookieNames {
+		names[strings.ToLower(name)] = true
+	}
+	return &defaultRedactor{headerGlobs: headerGlobs, cookieNames: names, jsonPaths: jsonPaths}
+}
+
+func (dr *defaultRedactor) RedactHeader(name, value string) string {
+	lower := strings.ToLower(strings.TrimSpace(name))
+
+	switch lower {
+	case "cookie":
+		return dr.redactCookieHeader(value)
+	case "set-cookie":
+		return dr.redactSetCookieHeader(value)
+	}
+
+	for _, glob := range dr.headerGlobs {
+		if ok, _ := path.Match(strings.ToLower(glob), lower); ok {
+			return "********"
+		}
+	}
+
+	if isLegacyRedactedHeader(lower) {
+		return "********"
+	}
+	return value
+}
+
+func isLegacyRedactedHeader(lowerName string) bool {
+	if lowerName == "authorization" {
+		return true
+	}
+	for _, suffix := range legacyRedactedHeaderSuffixes {
+		if strings.HasSuffix(lowerName, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactCookieHeader masks configured cookie values in a request's Cookie
+// header, where every "; "-separated part is a name=value pair.
+func (dr *defaultRedactor) redactCookieHeader(value string) string {
+	if len(dr.cookieNames) == 0 {
+		return value
+	}
+	parts := strings.Split(value, ";")
+	for i, part := range parts {
+		leading := ""
+		trimmed := strings.TrimLeft(part, " ")
+		leading = part[:len(part)-len(trimmed)]
+
+		name, val, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		if dr.cookieNames[strings.ToLower(strings.TrimSpace(name))] {
+			val = "********"
+		}
+		parts[i] = leading + name + "=" + val
+	}
+	return strings.Join(parts, ";")
+}
+
+// redactSetCookieHeader masks a configured cookie's value in a response's
+// Set-Cookie header, where only the first "; "-separated part is the
+// name=value pair and the rest are attributes (Path, Max-Age, HttpOnly...)
+// that must be preserved as-is.
+func (dr *defaultRedactor) redactSetCookieHeader(value string) string {
+	if len(dr.cookieNames) == 0 {
+		return value
+	}
+	parts := strings.Split(value, ";")
+	if len(parts) == 0 {
+		return value
+	}
+	name, _, ok := strings.Cut(parts[0], "=")
+	if ok && dr.cookieNames[strings.ToLower(strings.TrimSpace(name))] {
+		parts[0] = name + "=********"
+	}
+	return strings.Join(parts, ";")
+}
+
+func (dr *defaultRedactor) RedactBody(contentType string, body []byte) []byte {
+	if len(dr.jsonPaths) == 0 || !isJSONContentType(contentType) {
+		return body
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return body
+	}
+
+	masked := false
+	for _, jsonPath := range dr.jsonPaths {
+		if maskJSONPath(doc, jsonPath) {
+			masked = true
+		}
+	}
+	if !masked {
+		return body
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// isJSONContentType reports whether a Content-Type header names a JSON
+// media type, ignoring any parameters (e.g. "; charset=utf-8").
+func isJSONContentType(contentType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+// maskJSONPath masks the value at a simple "$.a.b.c" path within a decoded
+// JSON document, if present. It reports whether anything was masked.
+func maskJSONPath(doc any, jsonPath string) bool {
+	segments := strings.Split(strings.TrimPrefix(jsonPath, "$."), ".")
+
+	cursor := doc
+	for i, segment := range segments {
+		m, ok := cursor.(map[string]any)
+		if !ok {
+			return false
+		}
+		if i == len(segments)-1 {
+			if _, exists := m[segment]; !exists {
+				return false
+			}
+			m[segment] = "********"
+			return true
+		}
+		cursor, ok = m[segment]
+		if !ok {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package gin_lane
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsnab/go-lane"
+)
+
+// redactTestServer mirrors testServer but takes a GinLaneConfig so these
+// tests can register a custom Redactor.
+func redactTestServer(t *testing.T, opt GinLaneOptions, cfg GinLaneConfig) (tl lane.TestingLane, addr string) {
+	time.Sleep(time.Millisecond * 100) // allow localhost port to settle in
+
+	tl = lane.NewTestingLane(context.Background())
+	tl.WantDescendantEvents(true)
+	tl.AddTee(lane.NewLogLane(context.Background()))
+
+	ginGlobalsInitialized.Store(false)
+
+	router := NewGinRouterWithConfig(tl, opt, cfg)
+
+	router.GET("/cookie", func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=abc123; Path=/; HttpOnly")
+		c.String(http.StatusOK, "ok")
+	})
+
+	router.POST("/echo-json", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			panic(err)
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	srv := &http.Server{Handler: router}
+	ln, err := net.Listen("tcp", ":8602")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr = ln.Addr().String()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srv.Serve(ln)
+	}()
+
+	t.Cleanup(func() {
+		srv.Shutdown(tl)
+		wg.Wait()
+		srv.Close()
+	})
+	return
+}
+
+func TestCookieRedaction(t *testing.T) {
+	cfg := GinLaneConfig{Redactor: NewDefaultRedactor(nil, []string{"session"}, nil)}
+	tl, addr := redactTestServer(t, GinLaneOptionDumpRequest|GinLaneOptionDumpResponse, cfg)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/cookie", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Cookie", "session=xyz789; theme=dark")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	text := tl.EventsToString()
+	if !strings.Contains(text, "Cookie: session=********; theme=dark") {
+		t.Fatal("request cookie was not redacted")
+	}
+	if !strings.Contains(text, "Set-Cookie: session=********; Path=/; HttpOnly") {
+		t.Fatal("response cookie was not redacted")
+	}
+	if strings.Contains(text, "xyz789") || strings.Contains(text, "abc123") {
+		t.Fatal("raw cookie values leaked into the dump")
+	}
+}
+
+func TestJSONPathRedaction(t *testing.T) {
+	cfg := GinLaneConfig{Redactor: NewDefaultRedactor(nil, nil, []string{"$.password", "$.nested.token"})}
+	tl, addr := redactTestServer(t, GinLaneOptionDumpRequestBody|GinLaneOptionDumpResponseBody, cfg)
+
+	body := []byte(`{"password":"hunter2","nested":{"token":"secret-abc"},"user":"alice"}`)
+
+	resp, err := http.Post("http://"+addr+"/echo-json", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	text := tl.EventsToString()
+	if !strings.Contains(text, `"password":"********"`) || !strings.Contains(text, `"token":"********"`) {
+		t.Fatal("JSON path values were not redacted")
+	}
+	if strings.Contains(text, "hunter2") || strings.Contains(text, "secret-abc") {
+		t.Fatal("raw JSON secret values leaked into the dump")
+	}
+	if !strings.Contains(text, `"user":"alice"`) {
+		t.Fatal("unrelated JSON field should be left untouched")
+	}
+}
@@ -1,14 +1,14 @@
 package gin_lane
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
-	"net/http/httputil"
 	"regexp"
+	"sort"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +19,7 @@ type (
 	ginRequestHandler struct {
 		l   lane.Lane
 		opt GinLaneOptions
+		cfg GinLaneConfig
 	}
 
 	laneWriter struct {
@@ -27,11 +28,41 @@ type (
 		buf     bytes.Buffer
 	}
 
+	// GinLaneConfig holds tunables for the lane middleware's request/response
+	// dumping that don't belong in the GinLaneOptions bit field.
+	GinLaneConfig struct {
+		// MaxDumpBytes caps how many body bytes are captured for a
+		// request/response dump. The full body is still delivered to the
+		// handler (and to the client) - only the captured copy used for
+		// logging is bounded. A value <= 0 selects DefaultMaxDumpBytes.
+		MaxDumpBytes int
+
+		// Redactor scrubs sensitive header/body data out of request and
+		// response dumps. A nil Redactor selects the package's built-in
+		// default, which redacts the same header name patterns this
+		// package has always redacted.
+		Redactor Redactor
+	}
+
+	// teeReadCloser wraps an http.Request.Body so a bounded copy of what the
+	// handler reads is captured for dumping, without buffering the body
+	// ahead of the handler the way httputil.DumpRequest does.
+	teeReadCloser struct {
+		rc    io.ReadCloser
+		buf   bytes.Buffer
+		max   int
+		total int
+	}
+
 	responseCollector struct {
 		gin.ResponseWriter
-		written  bytes.Buffer
-		req      *http.Request
-		wantBody bool
+		written   bytes.Buffer
+		bodyBuf   bytes.Buffer
+		bodyTotal int
+		maxBody   int
+		req       *http.Request
+		wantBody  bool
+		redactor  Redactor
 	}
 
 	GinLaneOptions int
@@ -46,7 +77,10 @@ const (
 	GinLaneOptionDumpResponseBody
 )
 
-var crlf = []byte("\r\n")
+// DefaultMaxDumpBytes is the body capture cap used when a GinLaneConfig
+// doesn't specify MaxDumpBytes.
+const DefaultMaxDumpBytes = 64 * 1024
+
 var kRedactExp = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)^\s*authorization\s*:(.*)$`),
 	regexp.MustCompile(`(?i)^.*-token\s*:(.*)$`),
@@ -56,52 +90,112 @@ var kRedactExp = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)^.*-secret\s*:(.*)$`),
 }
 
+// reqHeaderDumpExclude mirrors net/http/httputil's own exclusion list so the
+// manually-built request dump reads the same as httputil.DumpRequest did.
+var reqHeaderDumpExclude = map[string]bool{
+	"Host":              true,
+	"Transfer-Encoding": true,
+	"Trailer":           true,
+}
+
 const kPanicAnsi = "\x1b[31m"
 const kColorOffAnsi = "\x1b[0m"
 
-var ginGlobalsInitialized sync.Once
+var ginGlobalsInitialized atomic.Bool
+
+func (cfg GinLaneConfig) maxDumpBytes() int {
+	if cfg.MaxDumpBytes > 0 {
+		return cfg.MaxDumpBytes
+	}
+	return DefaultMaxDumpBytes
+}
+
+// defaultPackageRedactor is the Redactor used when a GinLaneConfig doesn't
+// supply one.
+var defaultPackageRedactor = NewDefaultRedactor(nil, nil, nil)
+
+func (cfg GinLaneConfig) redactor() Redactor {
+	if cfg.Redactor != nil {
+		return cfg.Redactor
+	}
+	return defaultPackageRedactor
+}
 
 func initGin(l lane.Lane) {
 	// gin's got multiple ways of logging and some of them are singletons
-	ginGlobalsInitialized.Do(func() {
+	if ginGlobalsInitialized.CompareAndSwap(false, true) {
 		gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
 			l.Debugf("%s %#v %s handlers:%d", httpMethod, absolutePath, handlerName, nuHandlers)
 		}
 
 		gin.DefaultWriter = &laneWriter{l: l}
 		gin.DefaultErrorWriter = &laneWriter{l: l, isError: true}
-	})
+	}
 }
 
 // Provides a handler that ensures each gin request is associated with a lane
 func NewGinRouter(l lane.Lane, opt GinLaneOptions) (engine *gin.Engine) {
+	return NewGinRouterWithConfig(l, opt, GinLaneConfig{})
+}
+
+// NewGinRouterWithConfig is NewGinRouter with explicit control over dump
+// body capture limits via cfg.
+func NewGinRouterWithConfig(l lane.Lane, opt GinLaneOptions, cfg GinLaneConfig) (engine *gin.Engine) {
 	initGin(l)
 
 	engine = gin.New()
-	UseLaneMiddleware(engine, l, opt)
+	UseLaneMiddlewareWithConfig(engine, l, opt, cfg)
 	engine.Use(gin.Recovery())
 	return
 }
 
 // Attaches the lane logging/context middleware to the specified gin engine (aka router)
 func UseLaneMiddleware(engine *gin.Engine, l lane.Lane, opt GinLaneOptions) {
+	UseLaneMiddlewareWithConfig(engine, l, opt, GinLaneConfig{})
+}
+
+// UseLaneMiddlewareWithConfig is UseLaneMiddleware with explicit control over
+// dump body capture limits via cfg.
+func UseLaneMiddlewareWithConfig(engine *gin.Engine, l lane.Lane, opt GinLaneOptions, cfg GinLaneConfig) {
 	initGin(l)
 
-	glh := &ginRequestHandler{l: l, opt: opt}
+	glh := &ginRequestHandler{l: l, opt: opt, cfg: cfg}
 	engine.Use(glh.ginLaneMiddleware)
 }
 
+func newTeeReadCloser(rc io.ReadCloser, max int) *teeReadCloser {
+	return &teeReadCloser{rc: rc, max: max}
+}
+
+func (t *teeReadCloser) Read(p []byte) (n int, err error) {
+	n, err = t.rc.Read(p)
+	if n > 0 {
+		t.total += n
+		if room := t.max - t.buf.Len(); room > 0 {
+			if room > n {
+				room = n
+			}
+			t.buf.Write(p[:room])
+		}
+	}
+	return
+}
+
+func (t *teeReadCloser) Close() error {
+	return t.rc.Close()
+}
+
 func (glh *ginRequestHandler) ginLaneMiddleware(c *gin.Context) {
 	l2 := glh.l.Derive()
 	c.Request = c.Request.WithContext(l2)
 
-	if (glh.opt & (GinLaneOptionDumpRequest | GinLaneOptionDumpRequestBody)) != 0 {
-		raw, err := httputil.DumpRequest(c.Request, (glh.opt&GinLaneOptionDumpRequestBody) != 0)
-		if err != nil {
-			l2.Tracef("request dump error: %v", err)
-		} else {
-			dump(l2, "request-data", raw)
-		}
+	wantReqDump := (glh.opt & (GinLaneOptionDumpRequest | GinLaneOptionDumpRequestBody)) != 0
+	wantReqBody := (glh.opt & GinLaneOptionDumpRequestBody) != 0
+
+	var reqTee *teeReadCloser
+	if wantReqBody && c.Request.Body != nil {
+		reqTee = newTeeReadCloser(c.Request.Body, glh.cfg.maxDumpBytes())
+		c.Request.Body = reqTee
 	}
 
 	var collector *responseCollector
@@ -110,6 +204,8 @@ func (glh *ginRequestHandler) ginLaneMiddleware(c *gin.Context) {
 			ResponseWriter: c.Writer,
 			req:            c.Request,
 			wantBody:       (glh.opt & GinLaneOptionDumpResponseBody) != 0,
+			maxBody:        glh.cfg.maxDumpBytes(),
+			redactor:       glh.cfg.redactor(),
 		}
 		c.Writer = collector
 	}
@@ -117,24 +213,150 @@ func (glh *ginRequestHandler) ginLaneMiddleware(c *gin.Context) {
 	c.Next()
 
 	if (glh.opt & GinLaneOptionLogRequestResult) != 0 {
-		l2.Tracef("request: client=%s %s %#v status %d", c.ClientIP(), c.Request.Method, c.Request.RequestURI, c.Writer.Status())
+		l2.Tracef("request: client=%s %s %#v status %d", c.ClientIP(), c.Request.Method, requestURI(c.Request), c.Writer.Status())
+	}
+
+	if wantReqDump {
+		redactor := glh.cfg.redactor()
+		head := dumpRequestHead(c.Request, redactor)
+		var body []byte
+		total := 0
+		if reqTee != nil {
+			body = reqTee.buf.Bytes()
+			total = reqTee.total
+		}
+		emitDump(l2, "request-data", head, c.Request.Header.Get("Content-Type"), body, total, wantReqBody, redactor)
 	}
 
 	if collector != nil {
-		var raw []byte
+		contentType := collector.Header().Get("Content-Type")
+		emitDump(l2, "response-data", collector.written.Bytes(), contentType, collector.bodyBuf.Bytes(), collector.bodyTotal, collector.wantBody, collector.redactor)
+	}
+}
+
+// requestURI returns req.RequestURI, falling back to req.URL.RequestURI()
+// when it's unset - as it always is for requests net/http/cgi.RequestFromMap
+// builds from FastCGI params, since FastCGI has no request line on the wire.
+func requestURI(req *http.Request) string {
+	if req.RequestURI != "" {
+		return req.RequestURI
+	}
+	return req.URL.RequestURI()
+}
+
+// dumpRequestHead renders the request line and headers in the same wire
+// representation httputil.DumpRequest uses, without touching req.Body - so
+// it's always safe to call even while a handler is still reading the body.
+// Header values pass through redactor before being written.
+func dumpRequestHead(req *http.Request, redactor Redactor) []byte {
+	var b bytes.Buffer
+
+	reqURI := requestURI(req)
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	fmt.Fprintf(&b, "%s %s HTTP/%d.%d\r\n", method, reqURI, req.ProtoMajor, req.ProtoMinor)
+
+	absRequestURI := strings.HasPrefix(req.RequestURI, "http://") || strings.HasPrefix(req.RequestURI, "https://")
+	if !absRequestURI {
+		host := req.Host
+		if host == "" && req.URL != nil {
+			host = req.URL.Host
+		}
+		if host != "" {
+			fmt.Fprintf(&b, "Host: %s\r\n", host)
+		}
+	}
+
+	writeRedactedHeaders(&b, req.Header, reqHeaderDumpExclude, redactor)
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// dumpResponseHead renders a status line and headers in the same wire
+// representation httputil.DumpResponse uses. Header values pass through
+// redactor before being written.
+func dumpResponseHead(protoMajor, protoMinor, statusCode int, header http.Header, redactor Redactor) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "HTTP/%d.%d %d %s\r\n", protoMajor, protoMinor, statusCode, http.StatusText(statusCode))
+	writeRedactedHeaders(&b, header, nil, redactor)
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
 
-		reader := bufio.NewReader(&collector.written)
-		resp, err := http.ReadResponse(reader, c.Request)
-		if err == nil {
-			resp.Close = c.Request.Close
-			raw, err = httputil.DumpResponse(resp, (glh.opt&GinLaneOptionDumpResponseBody) != 0)
+// writeRedactedHeaders writes hdr in the same sorted "Name: value\r\n" form
+// net/http.Header.Write uses, skipping any name in exclude and passing every
+// value through redactor first.
+func writeRedactedHeaders(b *bytes.Buffer, hdr http.Header, exclude map[string]bool, redactor Redactor) {
+	keys := make([]string, 0, len(hdr))
+	for name := range hdr {
+		if !exclude[name] {
+			keys = append(keys, name)
 		}
-		if err != nil {
-			l2.Tracef("response dump error: %v", err)
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		for _, value := range hdr[name] {
+			fmt.Fprintf(b, "%s: %s\r\n", name, redactor.RedactHeader(name, value))
+		}
+	}
+}
+
+// isTextContentType reports whether a content type's body is safe to dump
+// as-is, vs. one better summarized (e.g. images, octet-stream).
+func isTextContentType(contentType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+	if contentType == "" {
+		return true
+	}
+	if strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	switch contentType {
+	case "application/json", "application/xml", "application/x-www-form-urlencoded",
+		"application/javascript", "application/ld+json", "application/yaml":
+		return true
+	}
+	return false
+}
+
+// emitDump traces head (request/status line + headers, already terminated
+// with a blank line) and, when wantBody is set, either the captured body
+// bytes (passed through redactor.RedactBody first) or a short summary when
+// the content type isn't text-like. totalBody may exceed len(body) when the
+// capture was truncated at the config's MaxDumpBytes limit.
+func emitDump(l lane.Lane, label string, head []byte, contentType string, body []byte, totalBody int, wantBody bool, redactor Redactor) {
+	raw := append([]byte(nil), head...)
+
+	if wantBody {
+		if !isTextContentType(contentType) {
+			raw = append(raw, []byte(binarySummary(contentType, body, totalBody))...)
 		} else {
-			dump(l2, "response-data", raw)
+			truncated := totalBody - len(body)
+			raw = append(raw, redactor.RedactBody(contentType, body)...)
+			if truncated > 0 {
+				raw = append(raw, []byte(fmt.Sprintf("\r\n... [truncated %d bytes]", truncated))...)
+			}
 		}
 	}
+
+	dump(l, label, raw)
+}
+
+func binarySummary(contentType string, body []byte, total int) string {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	n := len(body)
+	if n > 16 {
+		n = 16
+	}
+	return fmt.Sprintf("[non-text content-type %s, %d bytes, first %d hex: %x]", contentType, total, n, body[:n])
 }
 
 func redact(text string) string {
@@ -148,12 +370,14 @@ func redact(text string) string {
 	return text
 }
 
+// dump traces raw (headers already passed through a Redactor by
+// dumpRequestHead/dumpResponseHead, body by emitDump) one line at a time.
 func dump(l lane.Lane, context string, raw []byte) {
 	lines := strings.Split(string(raw), "\n")
 	for _, line := range lines {
 		text := strings.ReplaceAll(line, "\r", "")
 		if strings.TrimSpace(text) != "" {
-			l.Tracef("%s: %s", context, redact(text))
+			l.Tracef("%s: %s", context, text)
 		}
 	}
 }
@@ -214,20 +438,17 @@ func (lw *laneWriter) Write(data []byte) (written int, err error) {
 
 func (w *responseCollector) Write(b []byte) (int, error) {
 	if w.req != nil {
-		w.written.WriteString(fmt.Sprintf("HTTP/%d.%d %d %s%s", w.req.ProtoMajor, w.req.ProtoMinor, w.Status(), http.StatusText(w.Status()), crlf))
-		hdr := w.Header().Clone()
-		err := hdr.Write(&w.written)
-		if err != nil {
-			return 0, err
-		}
-		_, err = w.written.Write(crlf)
-		if err != nil {
-			return 0, err
-		}
+		w.written.Write(dumpResponseHead(w.req.ProtoMajor, w.req.ProtoMinor, w.Status(), w.Header(), w.redactor))
 		w.req = nil
 	}
 	if w.wantBody {
-		w.written.Write(b)
+		w.bodyTotal += len(b)
+		if room := w.maxBody - w.bodyBuf.Len(); room > 0 {
+			if room > len(b) {
+				room = len(b)
+			}
+			w.bodyBuf.Write(b[:room])
+		}
 	}
 
 	return w.ResponseWriter.Write(b)